@@ -2,10 +2,14 @@ package perf
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptrace"
+	"sync"
+	"time"
+
 	"xframe/log"
 	"xframe/utils"
 )
@@ -26,7 +30,7 @@ type DefaultPerf struct {
 	Url     string
 	Body    []byte
 	stopChs []chan struct{}
-	results []chan Result
+	results chan Result
 }
 
 func initDefaultPerf(number uint32, cc int, qps int, method string, url string, body []byte) *DefaultPerf {
@@ -38,26 +42,41 @@ func initDefaultPerf(number uint32, cc int, qps int, method string, url string,
 	this.Method = method
 	this.Url = url
 	this.Body = body
-	this.stopCh = make([]chan struct{}, this.Cc)
-	this.results = make([]chan Result, this.Number)
+	this.stopChs = make([]chan struct{}, this.Cc)
+	for i := range this.stopChs {
+		this.stopChs[i] = make(chan struct{})
+	}
+	// single buffered channel of Number capacity, not one chan per
+	// request: every worker sends its Results here as they complete.
+	this.results = make(chan Result, this.Number)
+	return this
 }
 
-func (this *DefaultPerf) makeRequest() (http.Request, error) {
+func (this *DefaultPerf) makeRequest() (*http.Request, error) {
 	if this.Method == "GET" {
 		return http.NewRequest(this.Method, this.Url, nil)
 	} else if this.Method == "POST" {
 		buf := bytes.NewBuffer(this.Body)
 		return http.NewRequest(this.Method, this.Url, buf)
 	}
+	return nil, fmt.Errorf("perf: unsupported method %q", this.Method)
 }
 
-func (this *DefaultPerf) DoRequest(client http.Client) {
+// DoRequest issues a single request and pushes its Result onto
+// this.results. intended is when the QPS schedule meant this request to
+// start; it's carried through so the Result can record how much
+// queue-wait time (coordinated omission) the request actually incurred.
+func (this *DefaultPerf) DoRequest(client http.Client, intended time.Time) {
 	s := time.Now()
 	var size int64
 	var code int
 	var dnsStart, connStart, resStart, reqStart, delayStart time.Time
 	var dnsDuration, connDuration, resDuration, reqDuration, delayDuration time.Duration
-	req := this.makeRequest()
+	req, err := this.makeRequest()
+	if err != nil {
+		this.results <- Result{err: err}
+		return
+	}
 	trace := &httptrace.ClientTrace{
 		DNSStart: func(info httptrace.DNSStartInfo) {
 			dnsStart = time.Now()
@@ -93,89 +112,77 @@ func (this *DefaultPerf) DoRequest(client http.Client) {
 	resDuration = t.Sub(resStart)
 	finish := t.Sub(s)
 	this.results <- Result{
-		statusCode:    code,
-		duration:      finish,
-		err:           err,
-		contentLength: size,
-		connDuration:  connDuration,
-		dnsDuration:   dnsDuration,
-		reqDuration:   reqDuration,
-		resDuration:   resDuration,
-		delayDuration: delayDuration,
+		statusCode:        code,
+		duration:          finish,
+		err:               err,
+		contentLength:     size,
+		connDuration:      connDuration,
+		dnsDuration:       dnsDuration,
+		reqDuration:       reqDuration,
+		resDuration:       resDuration,
+		delayDuration:     delayDuration,
+		intended:          intended,
+		correctedDuration: t.Sub(intended),
 	}
 }
 
-func (this *DefaultPerf) runWorker(n uint32, stopCh chan struct{}) {
-	var counter uint32
-	tick := time.Tick(time.Duration(1000/this.Qps) * time.MilliSecond)
+// runWorker fires n requests against the intended-start schedule
+// start + i*period, where period is derived from the target QPS. Driving
+// off a fixed schedule rather than a free-running ticker is what lets
+// DoRequest compute a coordinated-omission-corrected latency: if the
+// generator falls behind, the next request still fires for its original
+// slot, and the gap between that slot and completion shows up as queued
+// latency instead of silently vanishing. Every dispatched DoRequest is
+// tracked on reqWg so the caller can wait for in-flight requests to
+// finish, not just for the scheduling loop itself to exit -- otherwise
+// Start would close this.results while a still-running DoRequest is
+// about to send on it.
+func (this *DefaultPerf) runWorker(n uint32, stopCh chan struct{}, reqWg *sync.WaitGroup) {
 	cli := http.Client{}
-	for {
+	start := time.Now()
+	period := time.Second / time.Duration(this.Qps)
+	for i := uint32(0); i < n; i++ {
+		intended := start.Add(time.Duration(i) * period)
 		select {
-		case <-tick:
-			couter++
-			if counter == n {
-				return
-			}
-			go this.DoRequest(cli)
 		case <-stopCh:
-			log.DEBUG("receive stop signal")
+			log.Debug.Println("receive stop signal")
 			return
+		case <-time.After(time.Until(intended)):
+			reqWg.Add(1)
+			go func() {
+				defer reqWg.Done()
+				this.DoRequest(cli, intended)
+			}()
 		}
 	}
 }
 
-func (this *DefaultPerf) Report() interface{} {
-	var r Report
-	for res := range this.results {
-		if res.err != nil {
-			r.errorDist[res.err.Error()]++
-		} else {
-			r.lats = append(r.lats, res.duration.Seconds())
-			r.avgTotal += res.duration.Seconds()
-			r.avgConn += res.connDuration.Seconds()
-			r.avgDelay += res.delayDuration.Seconds()
-			r.avgDns += res.dnsDuration.Seconds()
-			r.avgReq += res.reqDuration.Seconds()
-			r.avgRes += res.resDuration.Seconds()
-			r.connLats = append(r.connLats, res.connDuration.Seconds())
-			r.dnsLats = append(r.dnsLats, res.dnsDuration.Seconds())
-			r.reqLats = append(r.reqLats, res.reqDuration.Seconds())
-			r.delayLats = append(r.delayLats, res.delayDuration.Seconds())
-			r.resLats = append(r.resLats, res.resDuration.Seconds())
-			r.statusCodeDist[res.statusCode]++
-			if res.contentLength > 0 {
-				r.sizeTotal += res.contentLength
-			}
-		}
-	}
-	r.rps = float64(len(r.lats)) / r.total.Seconds()
-	r.average = r.avgTotal / float64(len(r.lats))
-	r.avgConn = r.avgConn / float64(len(r.lats))
-	r.avgDelay = r.avgDelay / float64(len(r.lats))
-	r.avgDns = r.avgDns / float64(len(r.lats))
-	r.avgReq = r.avgReq / float64(len(r.lats))
-	r.avgRes = r.avgRes / float64(len(r.lats))
-	r.fastest = r.lats[0]
-	r.slowest = r.lats[len(r.lats)-1]
-	return r
-}
-
 func (this *DefaultPerf) Start() (interface{}, error) {
+	start := time.Now()
 	//split into cc worker with number / cc request
-	sync.Add(this.Cc)
+	var wg sync.WaitGroup
+	var reqWg sync.WaitGroup
+	wg.Add(this.Cc)
 	for i := 0; i < this.Cc; i++ {
-		go func() {
-			this.runWorker(this.Number/uint32(this.Cc), this.stopChs[i])
-			sync.Done()
-		}()
+		go func(i int) {
+			defer wg.Done()
+			this.runWorker(this.Number/uint32(this.Cc), this.stopChs[i], &reqWg)
+		}(i)
 	}
-	sync.Wait()
+	wg.Wait()
+	reqWg.Wait()
 	close(this.results)
-	return this.Report(), nil
+	report := newReport(time.Since(start))
+	for res := range this.results {
+		report.record(res)
+	}
+	report.finish()
+	return report, nil
 }
 
 func (this *DefaultPerf) Stop() error {
-	for ch := range this.stopChs {
-		go close(ch)
+	for _, ch := range this.stopChs {
+		close(ch)
 	}
+	return nil
 }
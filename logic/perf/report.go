@@ -0,0 +1,219 @@
+package perf
+
+import (
+	"io"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+)
+
+const (
+	histogramMin     = int64(time.Microsecond)
+	histogramMax     = int64(60 * time.Second)
+	histogramSigFigs = 3
+)
+
+// Result is a single HTTP request's outcome, produced by DoRequest and
+// consumed by Report.record.
+type Result struct {
+	statusCode    int
+	duration      time.Duration
+	err           error
+	contentLength int64
+	connDuration  time.Duration
+	dnsDuration   time.Duration
+	reqDuration   time.Duration
+	resDuration   time.Duration
+	delayDuration time.Duration
+	// intended is when the QPS schedule meant this request to start;
+	// correctedDuration is the completion time minus intended, capturing
+	// queue-wait time the load generator accumulates if it falls behind
+	// schedule (coordinated omission), which duration alone hides.
+	intended          time.Time
+	correctedDuration time.Duration
+}
+
+// histogram is a fixed-memory HDR histogram covering 1us to 60s at 3
+// significant figures, enough resolution for tail latencies without the
+// unbounded memory growth of keeping every sample.
+type histogram struct {
+	*hdrhistogram.Histogram
+}
+
+func newHistogram() *histogram {
+	return &histogram{hdrhistogram.New(histogramMin, histogramMax, histogramSigFigs)}
+}
+
+func (h *histogram) record(d time.Duration) {
+	h.RecordValue(int64(d))
+}
+
+// Percentiles summarizes a histogram at the percentiles operators care
+// about for load-test tail latency.
+type Percentiles struct {
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	P999 time.Duration
+	Max  time.Duration
+}
+
+func (h *histogram) percentiles() Percentiles {
+	return Percentiles{
+		P50:  time.Duration(h.ValueAtQuantile(50)),
+		P90:  time.Duration(h.ValueAtQuantile(90)),
+		P99:  time.Duration(h.ValueAtQuantile(99)),
+		P999: time.Duration(h.ValueAtQuantile(99.9)),
+		Max:  time.Duration(h.Max()),
+	}
+}
+
+// HistogramSnapshot is the serializable form of every phase histogram a
+// Report tracks: bucket counts plus the config needed to reconstruct
+// them, for persisting a Report or merging several runs.
+type HistogramSnapshot struct {
+	Latency     *hdrhistogram.Snapshot // corrected, coordinated-omission aware
+	ServiceTime *hdrhistogram.Snapshot // observed, uncorrected
+	Conn        *hdrhistogram.Snapshot
+	DNS         *hdrhistogram.Snapshot
+	Req         *hdrhistogram.Snapshot
+	Res         *hdrhistogram.Snapshot
+	Delay       *hdrhistogram.Snapshot
+}
+
+// Report summarizes a completed DefaultPerf.Start run: throughput,
+// per-phase latency distributions kept as HDR histograms rather than raw
+// sample slices so memory stays fixed regardless of request count, and
+// response-size/status totals.
+type Report struct {
+	total time.Duration
+
+	lats      *histogram // corrected total latency
+	rawLats   *histogram // observed service time, uncorrected
+	connLats  *histogram
+	dnsLats   *histogram
+	reqLats   *histogram
+	resLats   *histogram
+	delayLats *histogram
+
+	avgTotal float64
+	avgConn  float64
+	avgDelay float64
+	avgDns   float64
+	avgReq   float64
+	avgRes   float64
+
+	rps     float64
+	count   int64
+	fastest time.Duration
+	slowest time.Duration
+
+	errorDist      map[string]int
+	statusCodeDist map[int]int
+	sizeTotal      int64
+}
+
+func newReport(total time.Duration) *Report {
+	return &Report{
+		total:          total,
+		lats:           newHistogram(),
+		rawLats:        newHistogram(),
+		connLats:       newHistogram(),
+		dnsLats:        newHistogram(),
+		reqLats:        newHistogram(),
+		resLats:        newHistogram(),
+		delayLats:      newHistogram(),
+		errorDist:      make(map[string]int),
+		statusCodeDist: make(map[int]int),
+	}
+}
+
+// Latency returns the corrected end-to-end latency distribution: for
+// every request this includes any queue-wait time the load generator
+// accumulated by falling behind the QPS schedule, not just the time the
+// server took to respond.
+func (r *Report) Latency() Percentiles { return r.lats.percentiles() }
+
+// ServiceTime returns the observed, uncorrected service-time
+// distribution: how long the server itself took to respond.
+func (r *Report) ServiceTime() Percentiles { return r.rawLats.percentiles() }
+
+func (r *Report) ConnLatency() Percentiles  { return r.connLats.percentiles() }
+func (r *Report) DNSLatency() Percentiles   { return r.dnsLats.percentiles() }
+func (r *Report) ReqLatency() Percentiles   { return r.reqLats.percentiles() }
+func (r *Report) ResLatency() Percentiles   { return r.resLats.percentiles() }
+func (r *Report) DelayLatency() Percentiles { return r.delayLats.percentiles() }
+
+// HistogramSnapshot returns a serializable snapshot of every phase's
+// histogram.
+func (r *Report) HistogramSnapshot() HistogramSnapshot {
+	return HistogramSnapshot{
+		Latency:     r.lats.Export(),
+		ServiceTime: r.rawLats.Export(),
+		Conn:        r.connLats.Export(),
+		DNS:         r.dnsLats.Export(),
+		Req:         r.reqLats.Export(),
+		Res:         r.resLats.Export(),
+		Delay:       r.delayLats.Export(),
+	}
+}
+
+// hdrTicksPerHalfDistance controls the percentile resolution WriteHDR
+// reports at, matching the 5 ticks per half-distance HdrHistogram's own
+// CLI tools (e.g. HistogramLogProcessor) default to.
+const hdrTicksPerHalfDistance = 5
+
+// WriteHDR writes the corrected latency histogram via HdrHistogram's own
+// PercentilesPrint, the plain-text percentile distribution format (value,
+// percentile, total count, 1/(1-percentile), plus a Mean/StdDeviation/Max
+// summary footer) that HdrHistogram's plotting and log-analysis tooling
+// expects as input.
+func (r *Report) WriteHDR(w io.Writer) error {
+	_, err := r.lats.Histogram.PercentilesPrint(w, hdrTicksPerHalfDistance, 1)
+	return err
+}
+
+func (r *Report) record(res Result) {
+	if res.err != nil {
+		r.errorDist[res.err.Error()]++
+		return
+	}
+	r.count++
+	r.rawLats.record(res.duration)
+	r.lats.record(res.correctedDuration)
+	r.connLats.record(res.connDuration)
+	r.dnsLats.record(res.dnsDuration)
+	r.reqLats.record(res.reqDuration)
+	r.delayLats.record(res.delayDuration)
+	r.resLats.record(res.resDuration)
+	r.statusCodeDist[res.statusCode]++
+	if res.contentLength > 0 {
+		r.sizeTotal += res.contentLength
+	}
+	r.avgTotal += res.duration.Seconds()
+	r.avgConn += res.connDuration.Seconds()
+	r.avgDelay += res.delayDuration.Seconds()
+	r.avgDns += res.dnsDuration.Seconds()
+	r.avgReq += res.reqDuration.Seconds()
+	r.avgRes += res.resDuration.Seconds()
+	if r.count == 1 || res.duration < r.fastest {
+		r.fastest = res.duration
+	}
+	if res.duration > r.slowest {
+		r.slowest = res.duration
+	}
+}
+
+func (r *Report) finish() {
+	if r.count == 0 {
+		return
+	}
+	n := float64(r.count)
+	r.rps = n / r.total.Seconds()
+	r.avgTotal /= n
+	r.avgConn /= n
+	r.avgDelay /= n
+	r.avgDns /= n
+	r.avgReq /= n
+	r.avgRes /= n
+}
@@ -0,0 +1,46 @@
+package perf
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestReportCorrectedVsRawLatency verifies that ServiceTime (the
+// uncorrected time the server took) and Latency (the coordinated-
+// omission corrected latency, which includes queue-wait when the
+// generator falls behind schedule) are tracked as separate histograms
+// rather than collapsing to the same number.
+func TestReportCorrectedVsRawLatency(t *testing.T) {
+	r := newReport(time.Second)
+	// The server answered in 10ms, but the request was only dispatched
+	// 100ms after its intended schedule slot, so the corrected latency
+	// should reflect the full 110ms while the raw service time stays 10ms.
+	r.record(Result{
+		statusCode:        200,
+		duration:          10 * time.Millisecond,
+		correctedDuration: 110 * time.Millisecond,
+	})
+	r.finish()
+
+	svc := r.ServiceTime()
+	lat := r.Latency()
+	if svc.P50 < 9*time.Millisecond || svc.P50 > 11*time.Millisecond {
+		t.Fatalf("ServiceTime P50 = %v, want ~10ms", svc.P50)
+	}
+	if lat.P50 < 100*time.Millisecond {
+		t.Fatalf("Latency P50 = %v, want >= 100ms (should include queue-wait)", lat.P50)
+	}
+}
+
+func TestReportErrorsDoNotRecordLatency(t *testing.T) {
+	r := newReport(time.Second)
+	r.record(Result{err: errors.New("boom")})
+	r.finish()
+	if r.count != 0 {
+		t.Fatalf("count = %d, want 0 for an error result", r.count)
+	}
+	if got := r.errorDist["boom"]; got != 1 {
+		t.Fatalf("errorDist[\"boom\"] = %d, want 1", got)
+	}
+}
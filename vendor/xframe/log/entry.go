@@ -0,0 +1,143 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Fields is a set of key/value pairs attached to a log Entry via
+// WithField(s) or WithError, rendered by the Formatter alongside the
+// message.
+type Fields map[string]interface{}
+
+// Entry carries the request-scoped context for a single log call: the
+// fields attached via WithField(s)/WithError, the caller site, and the
+// rendered message. Entry exposes the same Debugf/Infof/.../Errorf
+// surface as Logger; Logger's own Printf-style methods simply create an
+// empty Entry and forward to it, so existing call sites keep working
+// unchanged.
+type Entry struct {
+	logger *Logger
+	ReqId  string
+	Fields Fields
+
+	Level  int
+	Msg    string
+	Time   time.Time
+	Caller string
+}
+
+// WithFields returns a *Entry carrying fields, ready for Debugf/Infof/...
+// calls.
+func (l *Logger) WithFields(fields Fields) *Entry {
+	return &Entry{logger: l, Fields: fields}
+}
+
+// WithField is shorthand for WithFields(Fields{key: value}).
+func (l *Logger) WithField(key string, value interface{}) *Entry {
+	return l.WithFields(Fields{key: value})
+}
+
+// WithError is shorthand for WithField("error", err).
+func (l *Logger) WithError(err error) *Entry {
+	return l.WithField("error", err)
+}
+
+// WithFields returns a new *Entry with fields merged on top of e's
+// existing fields; e itself is left unmodified.
+func (e *Entry) WithFields(fields Fields) *Entry {
+	merged := make(Fields, len(e.Fields)+len(fields))
+	for k, v := range e.Fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Entry{logger: e.logger, ReqId: e.ReqId, Fields: merged}
+}
+
+// WithField is shorthand for WithFields(Fields{key: value}).
+func (e *Entry) WithField(key string, value interface{}) *Entry {
+	return e.WithFields(Fields{key: value})
+}
+
+// WithError is shorthand for WithField("error", err).
+func (e *Entry) WithError(err error) *Entry {
+	return e.WithField("error", err)
+}
+
+// write renders and dispatches one log call from e. It operates on a
+// copy of e rather than e itself: outputEntry stamps Level/Msg/Time/
+// Caller in place and hands the Entry to hooks on their own goroutine, so
+// writing through the shared *Entry a builder chain (WithFields, ...)
+// hands back would race a later Infof/Errorf/... call against that hook
+// goroutine's read of the earlier one.
+func (e *Entry) write(lvl int, msg string) {
+	if e.logger == nil {
+		return
+	}
+	entry := *e
+	entry.logger.outputEntry(&entry, lvl, 3, msg)
+}
+
+func (e *Entry) Debugf(format string, v ...interface{}) { e.write(Ldebug, fmt.Sprintf(format, v...)) }
+func (e *Entry) Debug(v ...interface{})                 { e.write(Ldebug, fmt.Sprintln(v...)) }
+func (e *Entry) Infof(format string, v ...interface{})  { e.write(Linfo, fmt.Sprintf(format, v...)) }
+func (e *Entry) Info(v ...interface{})                  { e.write(Linfo, fmt.Sprintln(v...)) }
+func (e *Entry) Warnf(format string, v ...interface{})  { e.write(Lwarn, fmt.Sprintf(format, v...)) }
+func (e *Entry) Warn(v ...interface{})                  { e.write(Lwarn, fmt.Sprintln(v...)) }
+func (e *Entry) Errorf(format string, v ...interface{}) { e.write(Lerror, fmt.Sprintf(format, v...)) }
+func (e *Entry) Error(v ...interface{})                 { e.write(Lerror, fmt.Sprintln(v...)) }
+
+// Formatter renders a log Entry to the bytes a Sink receives. The
+// returned slice should end in a newline.
+type Formatter interface {
+	Format(e *Entry) ([]byte, error)
+}
+
+// TextFormatter renders an Entry the way Logger always has: the header
+// produced by formatHeader2 followed by the message and, if any fields
+// were attached via WithField(s), a trailing space-separated key=value
+// list.
+type TextFormatter struct{}
+
+func (TextFormatter) Format(e *Entry) ([]byte, error) {
+	hd := e.logger.formatHeader2(e.Time, e.Level, e.ReqId, e.Caller)
+	var buf bytes.Buffer
+	buf.WriteString(hd)
+	buf.WriteString(strings.TrimSuffix(e.Msg, "\n"))
+	for k, v := range e.Fields {
+		fmt.Fprintf(&buf, " %s=%v", k, v)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// JSONFormatter renders an Entry as a single JSON object per line, safe
+// for ingestion by ELK/Loki: time, level, msg, caller, req_id, and any
+// fields attached via WithField(s), merged at the top level.
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(e *Entry) ([]byte, error) {
+	data := make(map[string]interface{}, len(e.Fields)+5)
+	for k, v := range e.Fields {
+		data[k] = v
+	}
+	data["time"] = e.Time.Format(time.RFC3339Nano)
+	data["level"] = level_flags[e.Level]
+	data["msg"] = strings.TrimSuffix(e.Msg, "\n")
+	if e.Caller != "" {
+		data["caller"] = e.Caller
+	}
+	if e.ReqId != "" {
+		data["req_id"] = e.ReqId
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
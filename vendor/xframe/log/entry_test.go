@@ -0,0 +1,44 @@
+package log
+
+import (
+	"sync"
+	"testing"
+)
+
+type discardWriter struct{}
+
+func (discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (discardWriter) Close() error                { return nil }
+
+type recordingHook struct {
+	mu  sync.Mutex
+	got []string
+}
+
+func (h *recordingHook) Fire(e *Entry) {
+	h.mu.Lock()
+	h.got = append(h.got, e.Msg)
+	h.mu.Unlock()
+}
+
+// TestEntryReuseDoesNotRaceHooks exercises the exact pattern WithFields
+// exists for: build an *Entry once, then log through it repeatedly from
+// multiple goroutines. Run with -race: outputEntry must not mutate the
+// shared *Entry a caller is holding onto, since a hook goroutine from an
+// earlier call could still be reading it.
+func TestEntryReuseDoesNotRaceHooks(t *testing.T) {
+	l := New(discardWriter{}, "", Ldefault)
+	h := &recordingHook{}
+	l.SetHooks(Hooks{h})
+
+	entry := l.WithField("req_id", "abc")
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry.Infof("message %d", i)
+		}(i)
+	}
+	wg.Wait()
+}
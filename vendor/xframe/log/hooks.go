@@ -0,0 +1,23 @@
+package log
+
+// Hook receives every log Entry as it's emitted, after the Entry has been
+// fully populated (level, message, fields, caller) but independent of
+// whichever Formatter rendered it, so a hook can forward structured
+// fields to an external system (Sentry, Kafka, a metrics counter)
+// without re-parsing the rendered text.
+type Hook interface {
+	Fire(e *Entry)
+}
+
+// Hooks is an ordered set of Hook implementations a Logger fires on
+// every Output/Entry call. Output fires hooks on their own goroutine so a
+// slow or blocking hook can't stall the write path. A Logger created by
+// NewRotate or NewSeverityRotate does not fire its hooks from Output.
+type Hooks []Hook
+
+// Fire calls every hook in order.
+func (hooks Hooks) Fire(e *Entry) {
+	for _, hook := range hooks {
+		hook.Fire(e)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -63,9 +65,9 @@ type Logger struct {
 	prefix              string     // prefix to write at beginning of each line
 	flag                int        // properties
 	Level               int
-	out                 io.WriteCloser // destination for output
-	buf                 chan []byte    // for accumulating text to write
-	isClosed            chan bool      // for accumulating text channel
+	sinks               []sinkEntry  // destinations for output, see sink.go
+	buf                 chan logEntry // for accumulating entries to write
+	isClosed            chan bool     // for accumulating text channel
 	levelStats          [6]int64
 	enableCallFuncDepth bool
 	callFuncDepth       int
@@ -74,14 +76,30 @@ type Logger struct {
 	//for rotate
 	rotate       bool
 	rotateLogger *RotateLogger
+	//glog-style V-level verbosity, see vmodule.go
+	verbosity int32
+	vmod      atomic.Value // *vmodule
+	vCache    atomic.Value // *sync.Map, keyed by PC
+	traceLoc  atomic.Value // map[string]struct{}, keyed by "file:line"
+	//structured logging, see entry.go
+	formatter atomic.Value // Formatter
 }
 
 // New creates a new Logger.   The out variable sets the
-// destination to which log data will be written.
+// destination to which log data will be written, wrapped as the
+// Logger's initial Sink; use AddSink to fan out to additional
+// destinations such as syslog.
 // The prefix appears at the beginning of each generated log line.
 // The flag argument defines the logging properties.
 func New(out io.WriteCloser, prefix string, flag int) *Logger {
-	logger := &Logger{out: out, prefix: prefix, buf: make(chan []byte, BUFFER_SIZE), isClosed: make(chan bool), Level: 1, flag: flag}
+	logger := &Logger{
+		sinks:    []sinkEntry{{sink: NewWriterSink(out), level: Lnop}},
+		prefix:   prefix,
+		buf:      make(chan logEntry, BUFFER_SIZE),
+		isClosed: make(chan bool),
+		Level:    1,
+		flag:     flag,
+	}
 	go RealWrite(logger)
 	return logger
 }
@@ -92,7 +110,20 @@ func NewRotate(dir, prefix, suffix string, size int64) (*Logger, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Logger{out: rl.logf, prefix: prefix, Level: 1, flag: Ldefault, rotate: true, rotateLogger: rl}, nil
+	return &Logger{prefix: prefix, Level: 1, flag: Ldefault, rotate: true, rotateLogger: rl}, nil
+}
+
+// NewSeverityRotate is like NewRotate but opens one rotating file per
+// severity (see RotateLogger.SetDailyRotate/SetBackup and
+// NewSeverityRotateLogger) instead of a single destination, giving the
+// standard "tail the ERROR file" operator workflow.
+func NewSeverityRotate(dir, prefix, suffix string, size int64) (*Logger, error) {
+	kbSize := size * 1024 * 1024
+	rl, err := NewSeverityRotateLogger(dir, prefix, suffix, kbSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{prefix: prefix, Level: 1, flag: Ldefault, rotate: true, rotateLogger: rl}, nil
 }
 
 func (l *Logger) SetHooks(hooks Hooks) {
@@ -107,14 +138,17 @@ func (l *Logger) enableLogDepth(flag bool, depth int) {
 	}
 }
 
-func (l *Logger) formatHeader2(t time.Time, lvl int, reqId string) string {
+// formatHeader2 renders the TextFormatter's line prefix: date, time,
+// reqId, level and, when caller is non-empty, the call site. caller is
+// resolved once per Entry by outputEntry so every Formatter sees the same
+// value rather than each re-walking the stack.
+func (l *Logger) formatHeader2(t time.Time, lvl int, reqId string, caller string) string {
 	prefix := l.prefix
 	var (
-		date   string
-		clock  string
-		reqid  string
-		level  string
-		source string
+		date  string
+		clock string
+		reqid string
+		level string
 	)
 	if l.flag&(Ldate|Ltime|Lmicroseconds) != 0 {
 		if l.flag&Ldate != 0 {
@@ -137,12 +171,9 @@ func (l *Logger) formatHeader2(t time.Time, lvl int, reqId string) string {
 	if l.flag&Llevel != 0 {
 		level = levels[lvl]
 	}
-	if l.enableCallFuncDepth {
-		pc, file, lineno, ok := runtime.Caller(l.callFuncDepth)
-		if ok {
-			_, filename := path.Split(file)
-			source = fmt.Sprintf("%s:%s:%d ", runtime.FuncForPC(pc).Name(), filename, lineno)
-		}
+	source := caller
+	if source != "" {
+		source += " "
 	}
 	header := prefix + date + clock + reqid + level + source
 	return header
@@ -168,40 +199,118 @@ func (l *Logger) Output(reqId string, lvl int, calldepth int, s string) error {
 	if l.rotate {
 		return l.rotateLogger.Output(reqId, lvl, calldepth, s)
 	}
+	return l.outputEntry(&Entry{logger: l, ReqId: reqId}, lvl, calldepth+1, s)
+}
+
+// outputEntry is the common path behind Output and Entry's Debugf/Infof/
+// ...: it stamps e, renders it through the Logger's Formatter, appends a
+// backtrace if -log_backtrace_at matches, fires hooks, and queues the
+// result for RealWrite.
+func (l *Logger) outputEntry(e *Entry, lvl int, calldepth int, msg string) error {
 	if lvl < l.Level {
 		return nil
 	}
-	now := time.Now() // get this early.
 	l.levelStats[lvl]++
-	hd := l.formatHeader2(now, lvl, reqId)
-	content := hd + s
-	if len(s) > 0 && s[len(s)-1] != '\n' {
-		content = content + "\n"
+	e.Level = lvl
+	e.Msg = msg
+	e.Time = time.Now() // get this early.
+	if l.enableCallFuncDepth {
+		if pc, file, lineno, ok := runtime.Caller(l.callFuncDepth); ok {
+			_, filename := path.Split(file)
+			e.Caller = fmt.Sprintf("%s:%s:%d", runtime.FuncForPC(pc).Name(), filename, lineno)
+		}
 	}
-	go l.hooks.Fire(level_flags[lvl], []byte(content))
-	l.buf <- []byte(content)
+	content, err := l.formatterOrDefault().Format(e)
+	if err != nil {
+		return err
+	}
+	if locs := l.traceLocations(); len(locs) > 0 {
+		if _, file, line, ok := runtime.Caller(calldepth); ok {
+			key := path.Base(file) + ":" + strconv.Itoa(line)
+			if _, hit := locs[key]; hit {
+				buf := make([]byte, 1<<16)
+				n := runtime.Stack(buf, false)
+				content = append(content, buf[:n]...)
+			}
+		}
+	}
+	go l.hooks.Fire(e)
+	l.buf <- logEntry{level: lvl, data: content}
 	return nil
 }
 
+// SetFormatter sets the Formatter used to render every Entry into the
+// bytes handed to sinks. The default is TextFormatter{}.
+func (l *Logger) SetFormatter(f Formatter) {
+	l.formatter.Store(f)
+}
+
+func (l *Logger) formatterOrDefault() Formatter {
+	if f, ok := l.formatter.Load().(Formatter); ok {
+		return f
+	}
+	return TextFormatter{}
+}
+
+// AddSink registers a sink that receives every entry logged at level or
+// above; pass Lnop to receive every level. Sinks are fanned out to in the
+// order they were added.
+func (l *Logger) AddSink(s Sink, level int) {
+	l.mu.Lock()
+	l.sinks = append(l.sinks, sinkEntry{sink: s, level: level})
+	l.mu.Unlock()
+}
+
+// RemoveSink unregisters a previously added sink and closes it.
+func (l *Logger) RemoveSink(s Sink) {
+	l.mu.Lock()
+	kept := l.sinks[:0]
+	for _, se := range l.sinks {
+		if se.sink != s {
+			kept = append(kept, se)
+		}
+	}
+	l.sinks = kept
+	l.mu.Unlock()
+	s.Close()
+}
+
+func (l *Logger) writeToSinks(e logEntry) {
+	l.mu.Lock()
+	sinks := l.sinks
+	l.mu.Unlock()
+	for _, se := range sinks {
+		if e.level < se.level {
+			continue
+		}
+		se.sink.Write(e.level, e.data)
+	}
+}
+
+func (l *Logger) closeSinks() {
+	l.mu.Lock()
+	sinks := l.sinks
+	l.mu.Unlock()
+	for _, se := range sinks {
+		se.sink.Close()
+	}
+}
+
 func RealWrite(l *Logger) {
 	for {
 		select {
-		case buf := <-l.buf:
-			l.mu.Lock()
-			l.out.Write(buf)
-			l.mu.Unlock()
+		case e := <-l.buf:
+			l.writeToSinks(e)
 		case <-l.isClosed:
 			for more := true; more; {
 				select {
-				case buf := <-l.buf:
-					l.mu.Lock()
-					l.out.Write(buf)
-					l.mu.Unlock()
+				case e := <-l.buf:
+					l.writeToSinks(e)
 				default:
 					more = false
 				}
 			}
-			l.out.Close()
+			l.closeSinks()
 			return
 		}
 	}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"fmt"
+	"os"
+)
+
+// SeverityLogger is a narrow view of a Logger fixed at a single severity,
+// in the style of glog/minio: users write log.Error.Println(...) and it
+// always logs at ERROR. Entries below the backing Logger's output level
+// are dropped, as for any other Output call.
+type SeverityLogger struct {
+	logger *Logger
+	level  int
+}
+
+func (s *SeverityLogger) Print(v ...interface{}) {
+	s.logger.Output("", s.level, 2, fmt.Sprint(v...))
+	s.maybeExit()
+}
+
+func (s *SeverityLogger) Printf(format string, v ...interface{}) {
+	s.logger.Output("", s.level, 2, fmt.Sprintf(format, v...))
+	s.maybeExit()
+}
+
+func (s *SeverityLogger) Println(v ...interface{}) {
+	s.logger.Output("", s.level, 2, fmt.Sprintln(v...))
+	s.maybeExit()
+}
+
+func (s *SeverityLogger) maybeExit() {
+	if s.level == Lfatal {
+		os.Exit(1)
+	}
+}
+
+// std is the default Logger backing the package-level severity streams
+// below; it writes to stderr until UseSeverityRotate rebinds it.
+var std = New(os.Stderr, "", Ldefault)
+
+// Debug, Info, Warn, Error and Fatal are package-level severity streams
+// following the glog/minio convention. Fatal.Print/Printf/Println call
+// os.Exit(1) after logging, like the standard library's log.Fatal.
+var (
+	Debug = &SeverityLogger{logger: std, level: Ldebug}
+	Info  = &SeverityLogger{logger: std, level: Linfo}
+	Warn  = &SeverityLogger{logger: std, level: Lwarn}
+	Error = &SeverityLogger{logger: std, level: Lerror}
+	Fatal = &SeverityLogger{logger: std, level: Lfatal}
+)
+
+// UseSeverityRotate rebinds Debug, Info, Warn, Error and Fatal to a
+// NewSeverityRotate Logger, so each stream cascades into its own rotating
+// file under dir.
+func UseSeverityRotate(dir, prefix, suffix string, size int64) error {
+	l, err := NewSeverityRotate(dir, prefix, suffix, size)
+	if err != nil {
+		return err
+	}
+	std = l
+	Debug.logger = l
+	Info.logger = l
+	Warn.logger = l
+	Error.logger = l
+	Fatal.logger = l
+	return nil
+}
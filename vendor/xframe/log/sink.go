@@ -0,0 +1,151 @@
+package log
+
+import (
+	"io"
+	"log/syslog"
+	"os"
+	"sync"
+)
+
+// logEntry is a fully formatted log line queued on Logger.buf, along with
+// the numeric level it was logged at so sinks can apply their own level
+// filter independent of the Logger's.
+type logEntry struct {
+	level int
+	data  []byte
+}
+
+// Sink is a log destination. Write receives the numeric level the entry
+// was logged at (one of Ldebug..Lfatal) and the fully formatted entry,
+// including trailing newline; Close releases any resources held by the
+// sink. A Logger fans every Output call out to its registered sinks,
+// letting a single Logger feed a file, syslog, and other destinations at
+// independent level thresholds. A Logger created by NewRotate or
+// NewSeverityRotate writes only to its own rotating files, not to sinks.
+type Sink interface {
+	Write(level int, entry []byte) error
+	Close() error
+}
+
+// sinkEntry pairs a Sink with the minimum level it accepts, so callers
+// can e.g. send everything to a rotating file but only WARN+ to syslog.
+type sinkEntry struct {
+	sink  Sink
+	level int
+}
+
+// writerSink adapts a plain io.WriteCloser, such as an *os.File or
+// os.Stderr, to Sink.
+type writerSink struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// NewWriterSink wraps any io.WriteCloser as a Sink.
+func NewWriterSink(w io.WriteCloser) Sink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Write(level int, entry []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(entry)
+	return err
+}
+
+func (s *writerSink) Close() error {
+	return s.w.Close()
+}
+
+// NewFileSink opens path for append, creating it if necessary, and wraps
+// it as a Sink.
+func NewFileSink(path string) (Sink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterSink(f), nil
+}
+
+// nopCloser adapts os.Stderr (which callers don't own) to io.WriteCloser.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// NewStderrSink returns a Sink that writes to os.Stderr. Close is a
+// no-op, since os.Stderr is not owned by the sink.
+func NewStderrSink() Sink {
+	return NewWriterSink(nopCloser{os.Stderr})
+}
+
+// rotateSink adapts a RotateLogger as a Sink, so rotation can be used as
+// one destination among several rather than a Logger's sole output.
+type rotateSink struct {
+	rl *RotateLogger
+}
+
+// NewRotatingFileSink wraps an already-configured RotateLogger as a Sink.
+func NewRotatingFileSink(rl *RotateLogger) Sink {
+	return &rotateSink{rl: rl}
+}
+
+// Write hands entry -- already fully formatted by the parent Logger's
+// Formatter -- straight to the underlying rotating file(s). It must not
+// go through rl.Output, which would format it a second time (its own
+// prefix/timestamp/level) on top of the header the parent Logger already
+// wrote, double-heading every line.
+func (s *rotateSink) Write(level int, entry []byte) error {
+	return s.rl.WriteRaw(level, entry)
+}
+
+func (s *rotateSink) Close() error {
+	s.rl.Close()
+	return nil
+}
+
+// syslogSink dials a local or remote syslog/rsyslog daemon and translates
+// our Ldebug..Lfatal levels into syslog severities.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink dials the syslog daemon and wraps it as a Sink. network
+// is "" or "unix" to dial the local syslog socket, or "tcp"/"udp" to dial
+// raddr; facility and tag are applied to every entry as in syslog.Dial.
+func NewSyslogSink(network, raddr string, facility syslog.Priority, tag string) (Sink, error) {
+	var w *syslog.Writer
+	var err error
+	if network == "" || network == "unix" {
+		w, err = syslog.New(facility|syslog.LOG_INFO, tag)
+	} else {
+		w, err = syslog.Dial(network, raddr, facility|syslog.LOG_INFO, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Write(level int, entry []byte) error {
+	msg := string(entry)
+	switch level {
+	case Ldebug:
+		return s.w.Debug(msg)
+	case Linfo:
+		return s.w.Info(msg)
+	case Lwarn:
+		return s.w.Warning(msg)
+	case Lerror:
+		return s.w.Err(msg)
+	case Lpanic, Lfatal:
+		return s.w.Crit(msg)
+	default:
+		return s.w.Info(msg)
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRotatingFileSinkDoesNotDoubleFormat makes sure a rotateSink writes
+// the entry it's handed -- already fully formatted by the parent
+// Logger's Formatter -- straight to disk, rather than re-formatting it a
+// second time through the RotateLogger's own Output/formatter.
+func TestRotatingFileSinkDoesNotDoubleFormat(t *testing.T) {
+	dir := t.TempDir()
+	rl, err := NewRotateLogger(dir, "app", "log", 10)
+	if err != nil {
+		t.Fatalf("NewRotateLogger: %v", err)
+	}
+	defer rl.Close()
+
+	l := New(discardWriter{}, "app", Ldefault)
+	l.AddSink(NewRotatingFileSink(rl), Lnop)
+	l.Printf("hello world")
+
+	matches, err := filepath.Glob(filepath.Join(dir, "app.*.log"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("expected exactly one rotated file, got %v (err %v)", matches, err)
+	}
+	content := waitForContent(t, matches[0])
+
+	if n := strings.Count(content, "[INFO]"); n != 1 {
+		t.Fatalf("content has %d [INFO] headers, want 1 (double-formatted): %q", n, content)
+	}
+	if !strings.Contains(content, "hello world") {
+		t.Fatalf("content missing message: %q", content)
+	}
+}
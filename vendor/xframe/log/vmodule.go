@@ -0,0 +1,232 @@
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Verbose is the value returned by V and VDepth. Its methods are
+// cheap no-ops when the requested level exceeds the effective verbosity
+// threshold for the call site, mirroring glog's V(n).Info* idiom.
+type Verbose struct {
+	enabled bool
+	logger  *Logger
+}
+
+// novmodLevel marks a cached PC that no -vmodule pattern matched, so
+// VDepth knows to fall back to the live global verbosity alone.
+const novmodLevel = int32(-1)
+
+// V reports whether verbosity at the given level is enabled for the
+// caller's call site. The effective threshold for a call site is the
+// larger of the logger's global verbosity (see SetVerbosity) and any
+// -vmodule pattern (see SetVModule) matching the caller's file or full
+// package path. Only the vmodule contribution is cached per program
+// counter; the global verbosity is re-read on every call so SetVerbosity
+// takes effect immediately even for call sites V has already evaluated.
+func (l *Logger) V(level int) Verbose {
+	return l.VDepth(2, level)
+}
+
+// VDepth is like V but resolves the call site calldepth frames up the
+// stack, for helpers that wrap V on behalf of their own caller.
+func (l *Logger) VDepth(calldepth int, level int) Verbose {
+	vmodLevel := novmodLevel
+	if pc, file, _, ok := runtime.Caller(calldepth); ok {
+		cache := l.vCacheMap()
+		if cached, found := cache.Load(pc); found {
+			vmodLevel = cached.(int32)
+		} else {
+			if v, matched := l.vmoduleFilter().level(file); matched {
+				vmodLevel = v
+			}
+			cache.Store(pc, vmodLevel)
+		}
+	}
+	threshold := atomic.LoadInt32(&l.verbosity)
+	if vmodLevel > threshold {
+		threshold = vmodLevel
+	}
+	return Verbose{enabled: int32(level) <= threshold, logger: l}
+}
+
+// Infof calls Logger.Output in the manner of Printf if this Verbose is
+// enabled, and is a no-op otherwise.
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if v.enabled {
+		v.logger.Output("", Linfo, 2, fmt.Sprintf(format, args...))
+	}
+}
+
+// Infoln calls Logger.Output in the manner of Println if this Verbose is
+// enabled, and is a no-op otherwise.
+func (v Verbose) Infoln(args ...interface{}) {
+	if v.enabled {
+		v.logger.Output("", Linfo, 2, fmt.Sprintln(args...))
+	}
+}
+
+// SetVerbosity sets the global glog-style verbosity threshold. V(n) is
+// enabled when n is less than or equal to this threshold, unless a
+// -vmodule pattern raises the effective threshold for that call site.
+// Takes effect immediately, even for call sites V has already evaluated,
+// since VDepth re-reads the global threshold on every call.
+func (l *Logger) SetVerbosity(level int) {
+	atomic.StoreInt32(&l.verbosity, int32(level))
+}
+
+// SetVModule reprograms the -vmodule filter from a comma-separated list
+// of pattern=level pairs, e.g. "client*=2,xframe/server/*=3". A pattern
+// containing a path separator is matched, with ".go" stripped, against
+// every path suffix of the full source path runtime.Caller reports for
+// the logging call site (so "xframe/server/*" matches a call site in
+// ".../xframe/server/handler.go" even though Caller reports an
+// absolute/module-cache path, not an import path); a bare-name pattern is
+// matched against just the caller's file base name. Reprogramming
+// invalidates the per-PC vmodule cache built up by V and VDepth.
+func (l *Logger) SetVModule(spec string) error {
+	vm, err := parseVModule(spec)
+	if err != nil {
+		return err
+	}
+	l.vmod.Store(vm)
+	l.vCache.Store(new(sync.Map))
+	return nil
+}
+
+// SetTraceLocation reprograms the -log_backtrace_at filter from a
+// comma-separated list of file:line pairs, e.g. "client.go:123,foo.go:45".
+// When a subsequent Output call's caller matches one of these locations,
+// the logged entry has a runtime.Stack(false) dump appended. A Logger
+// created by NewRotate or NewSeverityRotate does not check these
+// locations.
+func (l *Logger) SetTraceLocation(spec string) error {
+	locs := make(map[string]struct{})
+	if spec != "" {
+		for _, loc := range strings.Split(spec, ",") {
+			parts := strings.SplitN(loc, ":", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("log: malformed log_backtrace_at %q, want file:line", loc)
+			}
+			if _, err := strconv.Atoi(parts[1]); err != nil {
+				return fmt.Errorf("log: malformed log_backtrace_at %q: %v", loc, err)
+			}
+			locs[filepath.Base(parts[0])+":"+parts[1]] = struct{}{}
+		}
+	}
+	l.traceLoc.Store(locs)
+	return nil
+}
+
+func (l *Logger) vmoduleFilter() *vmodule {
+	if vm, ok := l.vmod.Load().(*vmodule); ok {
+		return vm
+	}
+	return nil
+}
+
+func (l *Logger) vCacheMap() *sync.Map {
+	if m, ok := l.vCache.Load().(*sync.Map); ok {
+		return m
+	}
+	m := new(sync.Map)
+	l.vCache.Store(m)
+	return m
+}
+
+func (l *Logger) traceLocations() map[string]struct{} {
+	if locs, ok := l.traceLoc.Load().(map[string]struct{}); ok {
+		return locs
+	}
+	return nil
+}
+
+type vmodulePattern struct {
+	pattern string
+	isPath  bool
+	level   int32
+}
+
+// vmodule holds the parsed -vmodule pattern list used to resolve a
+// per-call-site verbosity threshold.
+type vmodule struct {
+	patterns []vmodulePattern
+}
+
+func parseVModule(spec string) (*vmodule, error) {
+	vm := &vmodule{}
+	for _, entry := range strings.Split(spec, ",") {
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("log: malformed vmodule entry %q, want pattern=level", entry)
+		}
+		level, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("log: malformed vmodule level in %q: %v", entry, err)
+		}
+		vm.patterns = append(vm.patterns, vmodulePattern{
+			pattern: parts[0],
+			isPath:  strings.ContainsAny(parts[0], `/\`),
+			level:   int32(level),
+		})
+	}
+	return vm, nil
+}
+
+// level returns the highest threshold among the patterns matching file,
+// the full path reported by runtime.Caller for a logging call site, and
+// whether any pattern matched at all.
+func (vm *vmodule) level(file string) (int32, bool) {
+	if vm == nil || len(vm.patterns) == 0 {
+		return 0, false
+	}
+	base := strings.TrimSuffix(filepath.Base(file), ".go")
+	var best int32
+	matched := false
+	for _, p := range vm.patterns {
+		var ok bool
+		if p.isPath {
+			ok = matchesPathSuffix(p.pattern, file)
+		} else {
+			var err error
+			ok, err = filepath.Match(p.pattern, base)
+			if err != nil {
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+		if !matched || p.level > best {
+			best = p.level
+			matched = true
+		}
+	}
+	return best, matched
+}
+
+// matchesPathSuffix reports whether pattern glob-matches any slash-
+// joined suffix of file with its ".go" extension stripped. runtime.Caller
+// reports an absolute (or build-cache) source path, not an import path,
+// so a pattern like "xframe/server/*" can't be compared against the
+// whole path with a single filepath.Match -- it has to match the
+// meaningful tail of it instead.
+func matchesPathSuffix(pattern, file string) bool {
+	trimmed := strings.TrimSuffix(filepath.ToSlash(file), ".go")
+	segments := strings.Split(trimmed, "/")
+	for i := range segments {
+		suffix := strings.Join(segments[i:], "/")
+		if ok, err := filepath.Match(pattern, suffix); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package log
+
+import "testing"
+
+// TestSetVerbosityInvalidatesCachedCallSite makes sure a call site that
+// has already been evaluated by V still picks up a later SetVerbosity
+// change, since only the vmodule contribution -- not the combined
+// threshold -- is cached per PC.
+func TestSetVerbosityInvalidatesCachedCallSite(t *testing.T) {
+	l := New(discardWriter{}, "", Ldefault)
+	// Every check goes through this one V call site, so all three share
+	// the same PC and the later two hit the cache the first one filled.
+	v1 := func() bool { return l.V(1).enabled }
+	l.SetVerbosity(0)
+	if v1() {
+		t.Fatalf("V(1) enabled at verbosity 0")
+	}
+	l.SetVerbosity(5)
+	if !v1() {
+		t.Fatalf("V(1) still disabled after SetVerbosity(5) raised the threshold for an already-cached call site")
+	}
+	l.SetVerbosity(0)
+	if v1() {
+		t.Fatalf("V(1) still enabled after SetVerbosity(0) lowered the threshold back down")
+	}
+}
+
+func TestVModulePathPatternMatchesCallerSuffix(t *testing.T) {
+	vm, err := parseVModule("xframe/server/*=3")
+	if err != nil {
+		t.Fatalf("parseVModule: %v", err)
+	}
+	file := "/root/go/pkg/mod/example.com/mod@v1.2.3/xframe/server/handler.go"
+	level, matched := vm.level(file)
+	if !matched {
+		t.Fatalf("pattern %q did not match caller file %q", "xframe/server/*", file)
+	}
+	if level != 3 {
+		t.Fatalf("level = %d, want 3", level)
+	}
+}
+
+func TestVModuleBareNamePatternMatchesBasename(t *testing.T) {
+	vm, err := parseVModule("client*=2")
+	if err != nil {
+		t.Fatalf("parseVModule: %v", err)
+	}
+	level, matched := vm.level("/src/xframe/rpc/client_unary.go")
+	if !matched || level != 2 {
+		t.Fatalf("level, matched = %d, %v; want 2, true", level, matched)
+	}
+	if _, matched := vm.level("/src/xframe/rpc/server_unary.go"); matched {
+		t.Fatalf("bare-name pattern unexpectedly matched an unrelated file")
+	}
+}